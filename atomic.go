@@ -0,0 +1,121 @@
+package redis
+
+import (
+	"reflect"
+	"time"
+)
+
+// ExpiryUpdater is an optional interface an Item can implement to allow its
+// expiry to be changed in place after it has been Set, as used by
+// UpdateExpiry and Persist. Items that don't implement it can still only
+// have their expiry changed by replacing them with Set/GetSet.
+type ExpiryUpdater interface {
+	// SetExpiry updates the item's expiry. A zero time.Time means the
+	// item no longer expires, and Expires() should report false from
+	// then on.
+	SetExpiry(t time.Time)
+}
+
+// SetNX sets key to i only if key does not already exist, atomically with
+// respect to concurrent goroutines. Returns whether it was set.
+func (db *RedisDb) SetNX(key *string, i Item) bool {
+	db.Mu().Lock()
+	defer db.Mu().Unlock()
+	if db.exists(key) {
+		return false
+	}
+	db.set(key, i)
+	return true
+}
+
+// SetXX sets key to i only if key already exists, atomically with respect
+// to concurrent goroutines. Returns whether it was set.
+func (db *RedisDb) SetXX(key *string, i Item) bool {
+	db.Mu().Lock()
+	defer db.Mu().Unlock()
+	if !db.exists(key) {
+		return false
+	}
+	db.set(key, i)
+	return true
+}
+
+// SetIfEqual sets key to newItem only if key currently holds an item whose
+// Value() equals expected.Value(), atomically with respect to concurrent
+// goroutines. Returns whether it was set.
+func (db *RedisDb) SetIfEqual(key *string, expected, newItem Item) bool {
+	db.Mu().Lock()
+	defer db.Mu().Unlock()
+	current := db.get(key)
+	if current == nil || !reflect.DeepEqual(current.Value(), expected.Value()) {
+		return false
+	}
+	db.set(key, newItem)
+	return true
+}
+
+// GetSet sets key to i and returns the item that was previously stored
+// under key, or nil if it did not exist, atomically with respect to
+// concurrent goroutines.
+func (db *RedisDb) GetSet(key *string, i Item) Item {
+	db.Mu().Lock()
+	defer db.Mu().Unlock()
+	old := db.get(key)
+	db.set(key, i)
+	return old
+}
+
+// UpdateExpiry changes the expiry of an existing key in place without a
+// Get/mutate/Set round trip, so it can't race with the expiration reaper.
+// It only works for items implementing ExpiryUpdater; returns false if key
+// doesn't exist or its item doesn't implement it.
+func (db *RedisDb) UpdateExpiry(key *string, newExpiry time.Time) bool {
+	db.Mu().Lock()
+	defer db.Mu().Unlock()
+	i := db.get(key)
+	if i == nil {
+		return false
+	}
+	updater, ok := i.(ExpiryUpdater)
+	if !ok {
+		return false
+	}
+	updater.SetExpiry(newExpiry)
+	db.reindexExpiry(key, i)
+	return true
+}
+
+// Persist removes the expiry from key, dropping it from expiringKeys so
+// the reaper stops considering it. Returns false if key doesn't have an
+// expiry set, or its item doesn't implement ExpiryUpdater (in which case
+// its own Expires() will still report true and it must be replaced via
+// Set/GetSet instead).
+func (db *RedisDb) Persist(key *string) bool {
+	db.Mu().Lock()
+	defer db.Mu().Unlock()
+	if _, ok := db.expiringKeys[*key]; !ok {
+		return false
+	}
+	i := db.get(key)
+	updater, ok := i.(ExpiryUpdater)
+	if !ok {
+		return false
+	}
+	updater.SetExpiry(time.Time{})
+	db.reindexExpiry(key, i)
+	return true
+}
+
+// reindexExpiry refreshes expiringKeys for key/i after its expiry was
+// mutated in place by ExpiryUpdater.SetExpiry, and reports the change the
+// same way Set does so subscribers and the AOF log see it too instead of
+// silently diverging from the tracked state.
+func (db *RedisDb) reindexExpiry(key *string, i Item) {
+	if i.Expires() {
+		db.expiringKeys[*key] = i
+	} else {
+		delete(db.expiringKeys, *key)
+	}
+	db.notify(EventSet, *key, i.ValueType())
+	db.logSet(key, i)
+}
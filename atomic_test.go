@@ -0,0 +1,114 @@
+package redis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetNXSetsOnlyWhenAbsent(t *testing.T) {
+	db := NewRedisDb(0, nil)
+	key := "k"
+	if !db.SetNX(&key, benchItem{}) {
+		t.Fatalf("SetNX should succeed when key is absent")
+	}
+	if db.SetNX(&key, benchItem{}) {
+		t.Fatalf("SetNX should fail when key already exists")
+	}
+}
+
+func TestSetXXSetsOnlyWhenPresent(t *testing.T) {
+	db := NewRedisDb(0, nil)
+	key := "k"
+	if db.SetXX(&key, benchItem{}) {
+		t.Fatalf("SetXX should fail when key is absent")
+	}
+	db.Set(&key, benchItem{})
+	if !db.SetXX(&key, benchItem{}) {
+		t.Fatalf("SetXX should succeed when key already exists")
+	}
+}
+
+func TestSetIfEqualComparesValue(t *testing.T) {
+	db := NewRedisDb(0, nil)
+	key := "k"
+	db.Set(&key, &mutableExpiryItem{value: "v1"})
+
+	if db.SetIfEqual(&key, &mutableExpiryItem{value: "wrong"}, &mutableExpiryItem{value: "v2"}) {
+		t.Fatalf("SetIfEqual should fail when expected value doesn't match")
+	}
+	if !db.SetIfEqual(&key, &mutableExpiryItem{value: "v1"}, &mutableExpiryItem{value: "v2"}) {
+		t.Fatalf("SetIfEqual should succeed when expected value matches")
+	}
+	if got := db.Get(&key).Value(); got != "v2" {
+		t.Fatalf("Get(k).Value() = %v, want v2", got)
+	}
+}
+
+func TestGetSetReturnsPreviousValue(t *testing.T) {
+	db := NewRedisDb(0, nil)
+	key := "k"
+	if old := db.GetSet(&key, &mutableExpiryItem{value: "v1"}); old != nil {
+		t.Fatalf("GetSet on absent key should return nil, got %v", old)
+	}
+	old := db.GetSet(&key, &mutableExpiryItem{value: "v2"})
+	if old == nil || old.Value() != "v1" {
+		t.Fatalf("GetSet should return the previous item, got %v", old)
+	}
+	if got := db.Get(&key).Value(); got != "v2" {
+		t.Fatalf("Get(k).Value() = %v, want v2", got)
+	}
+}
+
+func TestUpdateExpiryNotifiesAndReindexes(t *testing.T) {
+	db := NewRedisDb(0, nil)
+	events := make(chan KeyEvent, 2)
+	db.Subscribe(EventSet, events)
+
+	key := "k"
+	item := &mutableExpiryItem{value: "v", expires: true, expiry: time.Now().Add(time.Hour)}
+	db.Set(&key, item)
+	<-events // drain the Set event
+
+	if !db.UpdateExpiry(&key, time.Now().Add(-time.Hour)) {
+		t.Fatalf("UpdateExpiry should succeed for an ExpiryUpdater item")
+	}
+	if !db.Expires(&key) {
+		t.Fatalf("Expires(k) should still be true, only the expiry moment changed")
+	}
+
+	select {
+	case e := <-events:
+		if e.Event != EventSet || e.Key != key {
+			t.Fatalf("unexpected event after UpdateExpiry: %+v", e)
+		}
+	default:
+		t.Fatalf("UpdateExpiry should notify subscribers like Set does")
+	}
+}
+
+func TestPersistRemovesExpiryAndNotifies(t *testing.T) {
+	db := NewRedisDb(0, nil)
+	events := make(chan KeyEvent, 2)
+	db.Subscribe(EventSet, events)
+
+	key := "k"
+	item := &mutableExpiryItem{value: "v", expires: true, expiry: time.Now().Add(time.Hour)}
+	db.Set(&key, item)
+	<-events // drain the Set event
+
+	if !db.Persist(&key) {
+		t.Fatalf("Persist should succeed on an expiring key")
+	}
+	if db.Expires(&key) {
+		t.Fatalf("Expires(k) should be false after Persist")
+	}
+
+	select {
+	case e := <-events:
+		if e.Event != EventSet || e.Key != key {
+			t.Fatalf("unexpected event after Persist: %+v", e)
+		}
+	default:
+		t.Fatalf("Persist should notify subscribers like Set does")
+	}
+}
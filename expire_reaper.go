@@ -0,0 +1,155 @@
+package redis
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// expirySampleSize is how many entries are examined from expiringKeys per
+	// sample, mirroring Redis's own active-expiration cycle.
+	expirySampleSize = 20
+
+	// expiryRepeatRatio is the fraction of a sample that must be expired
+	// before another sample is taken within the same tick.
+	expiryRepeatRatio = 0.25
+
+	// expiryTimeBudget bounds how long a single tick may keep resampling
+	// before yielding, so the reaper never stalls writers.
+	expiryTimeBudget = 25 * time.Millisecond
+)
+
+// StartExpiryReaper starts a background goroutine that periodically samples
+// expiringKeys and evicts expired ones, freeing the caller from having to
+// read a key to trigger its expiry. It mirrors Redis's sampled active-expire
+// cycle: each tick takes a sample of up to expirySampleSize keys, and if more
+// than expiryRepeatRatio of the sample was expired it resamples immediately,
+// bounded by expiryTimeBudget so a single tick can't stall writers.
+//
+// The returned CancelFunc stops the reaper; it is safe to call more than
+// once.
+func (db *RedisDb) StartExpiryReaper(interval time.Duration) context.CancelFunc {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				db.expireCycle()
+			}
+		}
+	}()
+	return cancel
+}
+
+// expireCycle runs one active-expiration cycle against db, sampling
+// expiringKeys and evicting any expired entries under the write lock.
+func (db *RedisDb) expireCycle() {
+	deadline := time.Now().Add(expiryTimeBudget)
+	for {
+		sampled, expired := db.expireSample()
+		if sampled == 0 {
+			return
+		}
+		if float64(expired)/float64(sampled) <= expiryRepeatRatio {
+			return
+		}
+		if time.Now().After(deadline) {
+			return
+		}
+	}
+}
+
+// expireSample takes up to expirySampleSize keys from expiringKeys and
+// evicts the ones that have expired, returning how many keys were sampled
+// and how many of them were expired.
+func (db *RedisDb) expireSample() (sampled, expired int) {
+	db.Mu().Lock()
+	defer db.Mu().Unlock()
+
+	for k, i := range db.expiringKeys {
+		if sampled >= expirySampleSize {
+			break
+		}
+		sampled++
+		key := k
+		if ItemExpired(i) {
+			expired++
+			db.deleteExpired(&key)
+		}
+	}
+	return sampled, expired
+}
+
+// reaperSupervisors tracks, per *Redis instance, the interval an active
+// Redis.StartExpiryReaper is running at plus the per-db cancels it has
+// started so far. It lives outside the Redis struct (owned elsewhere in
+// this package) so RedisDb(), when it lazily creates a new db, can still
+// notice a running supervisor and start a reaper for that db too.
+//
+// Using r itself as the map key means every *Redis with an uncancelled
+// reaper is pinned in memory until its CancelFunc runs and removes the
+// entry; see the leak warning on Redis.StartExpiryReaper.
+var (
+	reaperSupervisorsMu sync.Mutex
+	reaperSupervisors   = map[*Redis]*reaperSupervisor{}
+)
+
+type reaperSupervisor struct {
+	interval time.Duration
+	cancels  []context.CancelFunc
+}
+
+// StartExpiryReaper starts an active-expiration reaper on every RedisDb
+// known to this instance, fanning out a per-db StartExpiryReaper at the
+// given interval, and arms future dbs too: any RedisDb lazily created
+// afterwards via r.RedisDb(id) gets its own reaper started at the same
+// interval automatically. The returned CancelFunc stops all of them,
+// including ones started for dbs created after this call.
+//
+// Leak warning: to be able to arm those future dbs, r is kept as a key in
+// a package-level registry (reaperSupervisors) until the returned
+// CancelFunc is called. That registry entry holds a strong reference to
+// r, so r cannot be garbage-collected while it is uncancelled, no matter
+// how many other references to r are dropped. Callers that create and
+// discard many short-lived *Redis instances (tests, per-request setups,
+// reconnect logic) MUST call the returned CancelFunc once they're done
+// with r, typically via `defer cancel()`, or each such instance leaks for
+// the remaining lifetime of the process.
+func (r *Redis) StartExpiryReaper(interval time.Duration) context.CancelFunc {
+	sup := &reaperSupervisor{interval: interval}
+	for _, db := range r.RedisDbs() {
+		sup.cancels = append(sup.cancels, db.StartExpiryReaper(interval))
+	}
+
+	reaperSupervisorsMu.Lock()
+	reaperSupervisors[r] = sup
+	reaperSupervisorsMu.Unlock()
+
+	return func() {
+		reaperSupervisorsMu.Lock()
+		defer reaperSupervisorsMu.Unlock()
+		if reaperSupervisors[r] == sup {
+			delete(reaperSupervisors, r)
+		}
+		for _, cancel := range sup.cancels {
+			cancel()
+		}
+	}
+}
+
+// startReaperForNewDb is called by RedisDb() right after it lazily creates
+// db, so a reaper supervisor started before db existed still covers it.
+func startReaperForNewDb(r *Redis, db *RedisDb) {
+	reaperSupervisorsMu.Lock()
+	defer reaperSupervisorsMu.Unlock()
+	sup, ok := reaperSupervisors[r]
+	if !ok {
+		return
+	}
+	sup.cancels = append(sup.cancels, db.StartExpiryReaper(sup.interval))
+}
@@ -0,0 +1,50 @@
+package redis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpiryReaperEvictsExpiredKeys(t *testing.T) {
+	db := NewRedisDb(0, nil)
+	expired := "expired"
+	db.Set(&expired, expiredBenchItem{})
+	alive := "alive"
+	db.Set(&alive, benchItem{})
+
+	cancel := db.StartExpiryReaper(5 * time.Millisecond)
+	defer cancel()
+
+	deadline := time.Now().Add(time.Second)
+	for db.Exists(&expired) {
+		if time.Now().After(deadline) {
+			t.Fatalf("expired key was not evicted by the reaper in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if !db.Exists(&alive) {
+		t.Fatalf("reaper evicted a non-expiring key")
+	}
+}
+
+func TestExpiryReaperFiresOnDeleteAndNotify(t *testing.T) {
+	db := NewRedisDb(0, nil)
+	events := make(chan KeyEvent, 1)
+	db.Subscribe(EventExpired, events)
+
+	key := "expired"
+	db.Set(&key, expiredBenchItem{})
+
+	cancel := db.StartExpiryReaper(5 * time.Millisecond)
+	defer cancel()
+
+	select {
+	case e := <-events:
+		if e.Key != key || e.Event != EventExpired {
+			t.Fatalf("unexpected event: %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for EventExpired notification")
+	}
+}
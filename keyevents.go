@@ -0,0 +1,64 @@
+package redis
+
+// KeyEventMask is a bitmask of KeyEvent types a subscriber is interested in.
+type KeyEventMask uint8
+
+const (
+	// EventSet fires whenever a key is set via Set.
+	EventSet KeyEventMask = 1 << iota
+	// EventDel fires when a key is explicitly removed via Delete.
+	EventDel
+	// EventExpired fires when a key is evicted because its TTL elapsed,
+	// either lazily (GetOrExpired) or by the active-expiration reaper.
+	EventExpired
+
+	// EventAll matches every event type.
+	EventAll = EventSet | EventDel | EventExpired
+)
+
+// KeyEvent describes a single mutation of a key in a RedisDb.
+type KeyEvent struct {
+	// Db is the id of the database the key lives in.
+	Db DatabaseId
+	// Key is the affected key.
+	Key string
+	// Event is the kind of mutation that occurred.
+	Event KeyEventMask
+	// ValueType is the ValueType() of the item at the time of the event.
+	ValueType uint64
+}
+
+// keyEventSub is a single subscriber registered via Subscribe.
+type keyEventSub struct {
+	mask KeyEventMask
+	ch   chan<- KeyEvent
+}
+
+// Subscribe registers ch to receive KeyEvents matching events from this db.
+// Sends are non-blocking: a subscriber that falls behind misses events
+// rather than stalling Set/Delete/expiration, so callers should size ch
+// generously or drain it promptly.
+func (db *RedisDb) Subscribe(events KeyEventMask, ch chan<- KeyEvent) {
+	db.subsMu.Lock()
+	defer db.subsMu.Unlock()
+	db.subs = append(db.subs, keyEventSub{mask: events, ch: ch})
+}
+
+// notify fans event out to every subscriber whose mask matches.
+func (db *RedisDb) notify(event KeyEventMask, key string, valueType uint64) {
+	db.subsMu.RLock()
+	defer db.subsMu.RUnlock()
+	if len(db.subs) == 0 {
+		return
+	}
+	e := KeyEvent{Db: db.id, Key: key, Event: event, ValueType: valueType}
+	for _, s := range db.subs {
+		if s.mask&event == 0 {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+		}
+	}
+}
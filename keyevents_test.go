@@ -0,0 +1,56 @@
+package redis
+
+import "testing"
+
+func TestSubscribeReceivesSetAndDeleteEvents(t *testing.T) {
+	db := NewRedisDb(0, nil)
+	events := make(chan KeyEvent, 4)
+	db.Subscribe(EventAll, events)
+
+	key := "k"
+	db.Set(&key, benchItem{})
+	db.Delete(&key)
+
+	e := <-events
+	if e.Event != EventSet || e.Key != key || e.Db != db.Id() {
+		t.Fatalf("first event = %+v, want EventSet for %q", e, key)
+	}
+	e = <-events
+	if e.Event != EventDel || e.Key != key {
+		t.Fatalf("second event = %+v, want EventDel for %q", e, key)
+	}
+}
+
+func TestSubscribeMaskFiltersEvents(t *testing.T) {
+	db := NewRedisDb(0, nil)
+	events := make(chan KeyEvent, 4)
+	db.Subscribe(EventDel, events)
+
+	key := "k"
+	db.Set(&key, benchItem{})
+	db.Delete(&key)
+
+	select {
+	case e := <-events:
+		if e.Event != EventDel {
+			t.Fatalf("received event %+v, want only EventDel to pass the mask", e)
+		}
+	default:
+		t.Fatalf("expected the EventDel notification to be delivered")
+	}
+
+	select {
+	case e := <-events:
+		t.Fatalf("unexpected extra event delivered: %+v", e)
+	default:
+	}
+}
+
+func TestSubscribeNonBlockingSendDropsOnFullChannel(t *testing.T) {
+	db := NewRedisDb(0, nil)
+	events := make(chan KeyEvent) // unbuffered, never read from
+	db.Subscribe(EventSet, events)
+
+	key := "k"
+	db.Set(&key, benchItem{}) // must not block even though no one reads events
+}
@@ -0,0 +1,156 @@
+package redis
+
+import (
+	"io"
+	"time"
+
+	"github.com/csabakissmalta/redis/persistence"
+)
+
+// snapshotScanBatch is how many keys snapshot fetches per Scan call.
+// snapshot only ever takes a db's read lock: once for the whole key list,
+// on the first Scan call, and then once per key through Get.
+const snapshotScanBatch = 256
+
+// Snapshot writes every key in every RedisDb of r to w as a stream of
+// persistence.Records, RDB-style. Items whose ValueType() has no
+// persistence.Codec registered are skipped.
+func (r *Redis) Snapshot(w io.Writer) error {
+	for _, db := range r.RedisDbs() {
+		if err := db.snapshot(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshot writes db's keys to w. It walks db.Scan in batches instead of
+// ranging db.keys directly, so it never holds db's lock for more than a
+// single key at a time while it does the (potentially slow) WriteRecord
+// I/O, matching the contract Scan was introduced for.
+func (db *RedisDb) snapshot(w io.Writer) error {
+	cursor := uint64(0)
+	for {
+		var batch []string
+		cursor, batch = db.Scan(cursor, "", snapshotScanBatch)
+		for _, key := range batch {
+			if err := db.snapshotKey(w, key); err != nil {
+				return err
+			}
+		}
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+func (db *RedisDb) snapshotKey(w io.Writer, key string) error {
+	i := db.Get(&key)
+	if i == nil {
+		// Deleted between Scan's snapshot and now; nothing to persist.
+		return nil
+	}
+	payload, err := persistence.EncodePayload(i.ValueType(), i.Value())
+	if err != nil {
+		return nil
+	}
+	rec := persistence.Record{
+		DbId:      uint(db.id),
+		Key:       key,
+		Expires:   i.Expires(),
+		ValueType: i.ValueType(),
+		Payload:   payload,
+	}
+	if i.Expires() {
+		rec.ExpiryUnixNano = i.Expiry().UnixNano()
+	}
+	return persistence.WriteRecord(w, rec)
+}
+
+// ItemFactory reconstructs an Item from a decoded value during Restore.
+// Item is a user-defined interface, so Restore can't build one on its own;
+// callers supply the mapping from (valueType, value, expiry) back to a
+// concrete Item.
+type ItemFactory func(valueType uint64, value interface{}, expires bool, expiry time.Time) Item
+
+// Restore reconstructs dbs on r from a stream of Records written by
+// Snapshot, re-arming expiries via Set. It does not read a persistence.Log,
+// compacted or not: a Log prefixes each Record with its OpType and is read
+// back with persistence.Replay.
+func (r *Redis) Restore(rd io.Reader, newItem ItemFactory) error {
+	for {
+		rec, err := persistence.ReadRecord(rd)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		value, err := persistence.DecodePayload(rec.ValueType, rec.Payload)
+		if err != nil {
+			return err
+		}
+		var expiry time.Time
+		if rec.Expires {
+			expiry = time.Unix(0, rec.ExpiryUnixNano)
+		}
+		db := r.RedisDb(DatabaseId(rec.DbId))
+		key := rec.Key
+		db.Set(&key, newItem(rec.ValueType, value, rec.Expires, expiry))
+	}
+}
+
+// EnableAOF makes db report every Set/Delete/expiry mutation to w. Pass
+// nil, or call DisableAOF, to stop logging. A Set of an item whose
+// ValueType() has no persistence.Codec registered is not logged.
+func (db *RedisDb) EnableAOF(w persistence.Writer) {
+	db.aofMu.Lock()
+	defer db.aofMu.Unlock()
+	db.aof = w
+}
+
+// DisableAOF stops logging mutations for db.
+func (db *RedisDb) DisableAOF() {
+	db.EnableAOF(nil)
+}
+
+func (db *RedisDb) logSet(key *string, i Item) {
+	w := db.aofWriter()
+	if w == nil {
+		return
+	}
+	payload, err := persistence.EncodePayload(i.ValueType(), i.Value())
+	if err != nil {
+		return
+	}
+	rec := persistence.Record{
+		DbId:      uint(db.id),
+		Key:       *key,
+		Expires:   i.Expires(),
+		ValueType: i.ValueType(),
+		Payload:   payload,
+	}
+	if i.Expires() {
+		rec.ExpiryUnixNano = i.Expiry().UnixNano()
+	}
+	_ = w.Append(persistence.Op{Type: persistence.OpSet, Record: rec})
+}
+
+func (db *RedisDb) logDelete(key *string, event KeyEventMask) {
+	w := db.aofWriter()
+	if w == nil {
+		return
+	}
+	opType := persistence.OpDelete
+	if event == EventExpired {
+		opType = persistence.OpExpire
+	}
+	rec := persistence.Record{DbId: uint(db.id), Key: *key}
+	_ = w.Append(persistence.Op{Type: opType, Record: rec})
+}
+
+func (db *RedisDb) aofWriter() persistence.Writer {
+	db.aofMu.RLock()
+	defer db.aofMu.RUnlock()
+	return db.aof
+}
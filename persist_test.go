@@ -0,0 +1,105 @@
+package redis
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/csabakissmalta/redis/persistence"
+)
+
+type stringValueCodec struct{}
+
+func (stringValueCodec) Encode(w io.Writer, value interface{}) error {
+	s, _ := value.(string)
+	_, err := w.Write([]byte(s))
+	return err
+}
+
+func (stringValueCodec) Decode(r io.Reader) (interface{}, error) {
+	b, err := io.ReadAll(r)
+	return string(b), err
+}
+
+func init() {
+	persistence.Register(1, stringValueCodec{}) // matches mutableExpiryItem.ValueType()
+}
+
+func TestDbSnapshotEncodesRegisteredValueTypes(t *testing.T) {
+	db := NewRedisDb(0, nil)
+	key := "k"
+	db.Set(&key, &mutableExpiryItem{value: "hello"})
+	// benchItem's ValueType() has no registered Codec and must be skipped
+	// rather than failing the whole snapshot.
+	unregistered := "u"
+	db.Set(&unregistered, benchItem{})
+
+	var buf bytes.Buffer
+	if err := db.snapshot(&buf); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+
+	rec, err := persistence.ReadRecord(&buf)
+	if err != nil {
+		t.Fatalf("ReadRecord: %v", err)
+	}
+	if rec.Key != key || rec.ValueType != 1 {
+		t.Fatalf("unexpected record: %+v", rec)
+	}
+	value, err := persistence.DecodePayload(rec.ValueType, rec.Payload)
+	if err != nil {
+		t.Fatalf("DecodePayload: %v", err)
+	}
+	if value != "hello" {
+		t.Fatalf("decoded value = %v, want hello", value)
+	}
+
+	if _, err := persistence.ReadRecord(&buf); err != io.EOF {
+		t.Fatalf("snapshot should contain exactly one record, got extra: err=%v", err)
+	}
+}
+
+// fakeAOFWriter records every Op appended to it, standing in for a real
+// persistence.Log so tests don't need a filesystem.
+type fakeAOFWriter struct {
+	ops []persistence.Op
+}
+
+func (f *fakeAOFWriter) Append(op persistence.Op) error {
+	f.ops = append(f.ops, op)
+	return nil
+}
+
+func TestEnableAOFLogsSetAndDelete(t *testing.T) {
+	db := NewRedisDb(0, nil)
+	w := &fakeAOFWriter{}
+	db.EnableAOF(w)
+
+	key := "k"
+	db.Set(&key, &mutableExpiryItem{value: "v1"})
+	db.Delete(&key)
+
+	if len(w.ops) != 2 {
+		t.Fatalf("got %d logged ops, want 2: %+v", len(w.ops), w.ops)
+	}
+	if w.ops[0].Type != persistence.OpSet || w.ops[0].Record.Key != key {
+		t.Fatalf("first op = %+v, want OpSet for %q", w.ops[0], key)
+	}
+	if w.ops[1].Type != persistence.OpDelete || w.ops[1].Record.Key != key {
+		t.Fatalf("second op = %+v, want OpDelete for %q", w.ops[1], key)
+	}
+}
+
+func TestDisableAOFStopsLogging(t *testing.T) {
+	db := NewRedisDb(0, nil)
+	w := &fakeAOFWriter{}
+	db.EnableAOF(w)
+	db.DisableAOF()
+
+	key := "k"
+	db.Set(&key, &mutableExpiryItem{value: "v1"})
+
+	if len(w.ops) != 0 {
+		t.Fatalf("DisableAOF should stop further logging, got %+v", w.ops)
+	}
+}
@@ -0,0 +1,173 @@
+package persistence
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+)
+
+// OpType identifies the kind of mutation an Op records.
+type OpType uint8
+
+const (
+	// OpSet records a key being set; Op.Record carries its full value.
+	OpSet OpType = iota
+	// OpDelete records a key being explicitly removed; only
+	// Op.Record.DbId and Key are meaningful.
+	OpDelete
+	// OpExpire records a key being evicted because its TTL elapsed; only
+	// Op.Record.DbId and Key are meaningful.
+	OpExpire
+)
+
+// Op is a single append-only-log entry.
+type Op struct {
+	Type   OpType
+	Record Record
+}
+
+// FsyncPolicy controls how often a Log flushes to stable storage,
+// mirroring Redis's appendfsync setting.
+type FsyncPolicy uint8
+
+const (
+	// FsyncAlways fsyncs after every Append.
+	FsyncAlways FsyncPolicy = iota
+	// FsyncEverySec fsyncs on a background one-second ticker.
+	FsyncEverySec
+	// FsyncNo never fsyncs explicitly, leaving it to the OS.
+	FsyncNo
+)
+
+// Writer is implemented by anything that can persist an Op. RedisDb.EnableAOF
+// accepts a Writer so callers can plug in their own backend instead of Log.
+type Writer interface {
+	Append(op Op) error
+}
+
+// Log is a Writer that appends Ops to an underlying io.Writer, typically an
+// *os.File, honoring an FsyncPolicy.
+type Log struct {
+	mu     sync.Mutex
+	w      io.Writer
+	syncer func() error
+	policy FsyncPolicy
+	stop   context.CancelFunc
+}
+
+// NewLog wraps w into a Log flushed according to policy. If w implements
+// interface{ Sync() error } (as *os.File does) it is used for fsyncing;
+// otherwise FsyncAlways/FsyncEverySec degrade to relying on w.Write alone.
+func NewLog(w io.Writer, policy FsyncPolicy) *Log {
+	l := &Log{w: w, policy: policy}
+	if s, ok := w.(interface{ Sync() error }); ok {
+		l.syncer = s.Sync
+	}
+	if policy == FsyncEverySec {
+		ctx, cancel := context.WithCancel(context.Background())
+		l.stop = cancel
+		go l.everySecLoop(ctx)
+	}
+	return l
+}
+
+func (l *Log) everySecLoop(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			l.mu.Lock()
+			l.fsync()
+			l.mu.Unlock()
+		}
+	}
+}
+
+func (l *Log) fsync() {
+	if l.syncer != nil {
+		_ = l.syncer()
+	}
+}
+
+// Append writes op to the log, fsyncing immediately under FsyncAlways.
+func (l *Log) Append(op Op) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := writeOp(l.w, op); err != nil {
+		return err
+	}
+	if l.policy == FsyncAlways {
+		l.fsync()
+	}
+	return nil
+}
+
+// Close stops the background fsync goroutine started for FsyncEverySec, if
+// any. It does not close the underlying writer.
+func (l *Log) Close() {
+	if l.stop != nil {
+		l.stop()
+	}
+}
+
+func writeOp(w io.Writer, op Op) error {
+	if err := binary.Write(w, binary.BigEndian, uint8(op.Type)); err != nil {
+		return err
+	}
+	return WriteRecord(w, op.Record)
+}
+
+func readOp(r io.Reader) (Op, error) {
+	var t uint8
+	if err := binary.Read(r, binary.BigEndian, &t); err != nil {
+		return Op{}, err
+	}
+	rec, err := ReadRecord(r)
+	if err != nil {
+		return Op{}, err
+	}
+	return Op{Type: OpType(t), Record: rec}, nil
+}
+
+// Replay reads every Op from r in order, calling apply for each. It stops
+// at the first error; an r exhausted exactly on an Op boundary ends the
+// replay cleanly rather than returning io.EOF to the caller.
+func Replay(r io.Reader, apply func(Op) error) error {
+	for {
+		op, err := readOp(r)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := apply(op); err != nil {
+			return err
+		}
+	}
+}
+
+// Compact writes dst as a fresh AOF equivalent to snapshot (a stream of
+// Records as written by Redis.Snapshot), so replaying dst alone
+// reconstructs the same state without the history that produced it.
+// Callers typically write to a temporary file and rename it over the old
+// log once this returns.
+func Compact(dst io.Writer, snapshot io.Reader) error {
+	for {
+		rec, err := ReadRecord(snapshot)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := writeOp(dst, Op{Type: OpSet, Record: rec}); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// Package persistence provides the on-disk record format and value-type
+// codec registry shared by RedisDb snapshotting and append-only logging.
+// It knows nothing about redis.RedisDb or redis.Item so that Item
+// implementations living outside this module can register a Codec for
+// their ValueType() without an import cycle back to the redis package.
+package persistence
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Codec (de)serializes the payload for a single Item value type,
+// identified by Item.ValueType().
+type Codec interface {
+	Encode(w io.Writer, value interface{}) error
+	Decode(r io.Reader) (interface{}, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[uint64]Codec{}
+)
+
+// Register associates a Codec with a value type so Snapshot/Restore and
+// the AOF know how to (de)serialize items of that type. It panics on a
+// duplicate registration for the same value type.
+func Register(valueType uint64, c Codec) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, exists := registry[valueType]; exists {
+		panic(fmt.Sprintf("persistence: Codec already registered for value type %d", valueType))
+	}
+	registry[valueType] = c
+}
+
+func lookup(valueType uint64) (Codec, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	c, ok := registry[valueType]
+	return c, ok
+}
+
+// EncodePayload runs value through the Codec registered for valueType.
+func EncodePayload(valueType uint64, value interface{}) ([]byte, error) {
+	c, ok := lookup(valueType)
+	if !ok {
+		return nil, fmt.Errorf("persistence: no Codec registered for value type %d", valueType)
+	}
+	var buf bytes.Buffer
+	if err := c.Encode(&buf, value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodePayload runs payload through the Codec registered for valueType.
+func DecodePayload(valueType uint64, payload []byte) (interface{}, error) {
+	c, ok := lookup(valueType)
+	if !ok {
+		return nil, fmt.Errorf("persistence: no Codec registered for value type %d", valueType)
+	}
+	return c.Decode(bytes.NewReader(payload))
+}
+
+// Record is one persisted key: dbId, key name, expiry and an
+// already-encoded payload. It carries no dependency on the redis
+// package's types so it can be read back before any Item is constructed.
+type Record struct {
+	DbId           uint
+	Key            string
+	Expires        bool
+	ExpiryUnixNano int64
+	ValueType      uint64
+	Payload        []byte
+}
+
+// WriteRecord writes r to w in the snapshot/AOF on-disk framing.
+func WriteRecord(w io.Writer, r Record) error {
+	if err := binary.Write(w, binary.BigEndian, uint64(r.DbId)); err != nil {
+		return err
+	}
+	if err := writeBytes(w, []byte(r.Key)); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, r.Expires); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, r.ExpiryUnixNano); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, r.ValueType); err != nil {
+		return err
+	}
+	return writeBytes(w, r.Payload)
+}
+
+// ReadRecord reads a single Record from r. It returns io.EOF, unwrapped,
+// when r is exhausted exactly on a record boundary.
+func ReadRecord(r io.Reader) (Record, error) {
+	var rec Record
+
+	var dbId uint64
+	if err := binary.Read(r, binary.BigEndian, &dbId); err != nil {
+		return Record{}, err
+	}
+	rec.DbId = uint(dbId)
+
+	key, err := readBytes(r)
+	if err != nil {
+		return Record{}, err
+	}
+	rec.Key = string(key)
+
+	if err := binary.Read(r, binary.BigEndian, &rec.Expires); err != nil {
+		return Record{}, err
+	}
+	if err := binary.Read(r, binary.BigEndian, &rec.ExpiryUnixNano); err != nil {
+		return Record{}, err
+	}
+	if err := binary.Read(r, binary.BigEndian, &rec.ValueType); err != nil {
+		return Record{}, err
+	}
+
+	payload, err := readBytes(r)
+	if err != nil {
+		return Record{}, err
+	}
+	rec.Payload = payload
+
+	return rec, nil
+}
+
+func writeBytes(w io.Writer, b []byte) error {
+	if err := binary.Write(w, binary.BigEndian, uint32(len(b))); err != nil {
+		return err
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+func readBytes(r io.Reader) ([]byte, error) {
+	var n uint32
+	if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		// Normalize to nil so a zero-length Payload (e.g. logDelete's
+		// OpDelete/OpExpire records, which never set one) round-trips
+		// identically to what was written, instead of coming back as a
+		// non-nil empty slice that fails reflect.DeepEqual comparisons.
+		return nil, nil
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
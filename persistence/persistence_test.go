@@ -0,0 +1,135 @@
+package persistence
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+const testStringValueType = 42
+
+type stringCodec struct{}
+
+func (stringCodec) Encode(w io.Writer, value interface{}) error {
+	s, _ := value.(string)
+	_, err := w.Write([]byte(s))
+	return err
+}
+
+func (stringCodec) Decode(r io.Reader) (interface{}, error) {
+	b, err := io.ReadAll(r)
+	return string(b), err
+}
+
+func init() {
+	Register(testStringValueType, stringCodec{})
+}
+
+func TestEncodeDecodePayloadRoundTrip(t *testing.T) {
+	payload, err := EncodePayload(testStringValueType, "hello")
+	if err != nil {
+		t.Fatalf("EncodePayload: %v", err)
+	}
+	value, err := DecodePayload(testStringValueType, payload)
+	if err != nil {
+		t.Fatalf("DecodePayload: %v", err)
+	}
+	if value != "hello" {
+		t.Fatalf("DecodePayload = %v, want hello", value)
+	}
+}
+
+func TestEncodePayloadUnregisteredValueTypeErrors(t *testing.T) {
+	if _, err := EncodePayload(999, "x"); err == nil {
+		t.Fatalf("EncodePayload should error for an unregistered value type")
+	}
+}
+
+func TestWriteReadRecordRoundTrip(t *testing.T) {
+	rec := Record{DbId: 3, Key: "k", Expires: true, ExpiryUnixNano: 1234, ValueType: testStringValueType, Payload: []byte("v")}
+
+	var buf bytes.Buffer
+	if err := WriteRecord(&buf, rec); err != nil {
+		t.Fatalf("WriteRecord: %v", err)
+	}
+
+	got, err := ReadRecord(&buf)
+	if err != nil {
+		t.Fatalf("ReadRecord: %v", err)
+	}
+	if !reflect.DeepEqual(got, rec) {
+		t.Fatalf("ReadRecord = %+v, want %+v", got, rec)
+	}
+
+	if _, err := ReadRecord(&buf); err != io.EOF {
+		t.Fatalf("ReadRecord on exhausted buffer = %v, want io.EOF", err)
+	}
+}
+
+func TestLogAppendAndReplay(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewLog(&buf, FsyncNo)
+
+	ops := []Op{
+		{Type: OpSet, Record: Record{DbId: 0, Key: "a", ValueType: testStringValueType, Payload: []byte("1")}},
+		{Type: OpSet, Record: Record{DbId: 0, Key: "b", ValueType: testStringValueType, Payload: []byte("2")}},
+		{Type: OpDelete, Record: Record{DbId: 0, Key: "a"}},
+	}
+	for _, op := range ops {
+		if err := log.Append(op); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+
+	var replayed []Op
+	if err := Replay(&buf, func(op Op) error {
+		replayed = append(replayed, op)
+		return nil
+	}); err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+
+	if len(replayed) != len(ops) {
+		t.Fatalf("Replay produced %d ops, want %d", len(replayed), len(ops))
+	}
+	for i, op := range ops {
+		if !reflect.DeepEqual(replayed[i], op) {
+			t.Fatalf("op %d = %+v, want %+v", i, replayed[i], op)
+		}
+	}
+}
+
+func TestCompactRewritesSnapshotAsSetOps(t *testing.T) {
+	var snapshot bytes.Buffer
+	recs := []Record{
+		{DbId: 0, Key: "a", ValueType: testStringValueType, Payload: []byte("1")},
+		{DbId: 1, Key: "b", ValueType: testStringValueType, Payload: []byte("2")},
+	}
+	for _, rec := range recs {
+		if err := WriteRecord(&snapshot, rec); err != nil {
+			t.Fatalf("WriteRecord: %v", err)
+		}
+	}
+
+	var compacted bytes.Buffer
+	if err := Compact(&compacted, &snapshot); err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+
+	var got []Op
+	if err := Replay(&compacted, func(op Op) error {
+		got = append(got, op)
+		return nil
+	}); err != nil {
+		t.Fatalf("Replay compacted log: %v", err)
+	}
+	if len(got) != len(recs) {
+		t.Fatalf("compacted log has %d ops, want %d", len(got), len(recs))
+	}
+	for i, rec := range recs {
+		if got[i].Type != OpSet || !reflect.DeepEqual(got[i].Record, rec) {
+			t.Fatalf("compacted op %d = %+v, want OpSet %+v", i, got[i], rec)
+		}
+	}
+}
@@ -3,6 +3,8 @@ package redis
 import (
 	"sync"
 	"time"
+
+	"github.com/csabakissmalta/redis/persistence"
 )
 
 const (
@@ -25,6 +27,34 @@ type RedisDb struct {
 	// TODO long long avg_ttl;          /* Average TTL, just for stats */
 
 	redis *Redis
+
+	// mu guards keys, expiringKeys, tags and keyTags for this db only.
+	// Each RedisDb has its own lock so that a write to one db never
+	// blocks readers or writers of another; the Redis-level mutex only
+	// guards the redisDbs map itself.
+	mu sync.RWMutex
+
+	// subsMu guards subs. It is separate from the db-wide mutex so that
+	// notify can be called while that mutex is held without risking a
+	// subscriber callback deadlocking the db.
+	subsMu sync.RWMutex
+	subs   []keyEventSub
+
+	// tags maps a tag to the set of keys carrying it.
+	tags map[string]map[string]struct{}
+	// keyTags is the reverse index of tags, mapping a key to the set of
+	// tags it carries.
+	keyTags map[string]map[string]struct{}
+
+	// scanMu guards scans and nextScanToken for Scan/ScanExpiring.
+	scanMu        sync.Mutex
+	scans         map[uint64]*scanState
+	nextScanToken uint64
+
+	// aofMu guards aof, the append-only-log Writer mutations are
+	// reported to; nil means AOF logging is disabled for this db.
+	aofMu sync.RWMutex
+	aof   persistence.Writer
 }
 
 // Redis databases map
@@ -65,6 +95,9 @@ func NewRedisDb(id DatabaseId, r *Redis) *RedisDb {
 		redis:        r,
 		keys:         make(Keys, keysMapSize),
 		expiringKeys: make(Keys, keysMapSize),
+		tags:         make(map[string]map[string]struct{}),
+		keyTags:      make(map[string]map[string]struct{}),
+		scans:        make(map[uint64]*scanState),
 	}
 }
 
@@ -94,8 +127,10 @@ func (r *Redis) RedisDb(dbId DatabaseId) *RedisDb {
 		return db
 	}
 	// now really create db of that id
-	r.redisDbs[dbId] = NewRedisDb(dbId, r)
-	return r.redisDbs[dbId]
+	db = NewRedisDb(dbId, r)
+	r.redisDbs[dbId] = db
+	startReaperForNewDb(r, db)
+	return db
 }
 
 func (r *Redis) RedisDbs() RedisDbs {
@@ -109,9 +144,17 @@ func (db *RedisDb) Redis() *Redis {
 	return db.redis
 }
 
-// Mu gets the mutex.
+// Mu gets the mutex guarding this db's keys, expiringKeys, tags and
+// keyTags. It is private to this db: locking it has no effect on any
+// other RedisDb in the instance.
+//
+// Contract for Item implementors: OnDelete is invoked while this lock is
+// held, so an OnDelete implementation must not call back into the same
+// RedisDb (directly or transitively) or it will deadlock. Calling into a
+// different RedisDb, or into this db from another goroutine later, is
+// fine since each db's lock is independent.
 func (db *RedisDb) Mu() *sync.RWMutex {
-	return db.Redis().Mu()
+	return &db.mu
 }
 
 // Id gets the db id.
@@ -134,6 +177,10 @@ func (db *RedisDb) IsEmptyExpire() bool {
 }
 
 // Keys gets all keys in this db.
+//
+// Deprecated: this returns the live, mutable map under a lock that is
+// released before the caller can safely range over it, which races with
+// concurrent writers. Use Scan instead.
 func (db *RedisDb) Keys() Keys {
 	db.Mu().RLock()
 	defer db.Mu().RUnlock()
@@ -141,6 +188,8 @@ func (db *RedisDb) Keys() Keys {
 }
 
 // ExpiringKeys gets keys with an expiry set.
+//
+// Deprecated: same caveat as Keys; use ScanExpiring instead.
 func (db *RedisDb) ExpiringKeys() Keys {
 	db.Mu().RLock()
 	defer db.Mu().RUnlock()
@@ -151,10 +200,19 @@ func (db *RedisDb) ExpiringKeys() Keys {
 func (db *RedisDb) Set(key *string, i Item) {
 	db.Mu().Lock()
 	defer db.Mu().Unlock()
+	db.set(key, i)
+}
+
+func (db *RedisDb) set(key *string, i Item) {
 	db.keys[*key] = i
 	if i.Expires() {
 		db.expiringKeys[*key] = i
 	}
+	if !i.Expires() {
+		delete(db.expiringKeys, *key)
+	}
+	db.notify(EventSet, *key, i.ValueType())
+	db.logSet(key, i)
 }
 
 // Returns the item by the key or nil if key does not exists.
@@ -185,6 +243,16 @@ func (db *RedisDb) Delete(keys ...*string) int64 {
 
 // If checkExists is false, then return bool is reprehensible.
 func (db *RedisDb) delete(key *string) bool {
+	return db.deleteWithEvent(key, EventDel)
+}
+
+// deleteExpired removes key because its TTL elapsed, firing EventExpired
+// instead of EventDel.
+func (db *RedisDb) deleteExpired(key *string) bool {
+	return db.deleteWithEvent(key, EventExpired)
+}
+
+func (db *RedisDb) deleteWithEvent(key *string, event KeyEventMask) bool {
 	i := db.get(key)
 	if i == nil {
 		return false
@@ -192,6 +260,9 @@ func (db *RedisDb) delete(key *string) bool {
 	i.OnDelete(key, db)
 	delete(db.keys, *key)
 	delete(db.expiringKeys, *key)
+	db.untagAll(key)
+	db.notify(event, *key, i.ValueType())
+	db.logDelete(key, event)
 	return true
 }
 
@@ -226,7 +297,7 @@ func (db *RedisDb) GetOrExpired(key *string, deleteIfExpired bool) Item {
 	}
 	if ItemExpired(i) {
 		if deleteIfExpired {
-			db.delete(key)
+			db.deleteExpired(key)
 		}
 		return nil
 	}
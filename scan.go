@@ -0,0 +1,185 @@
+package redis
+
+import (
+	"bytes"
+	"sort"
+)
+
+// defaultScanCount is used when Scan/ScanExpiring is called with a
+// non-positive count.
+const defaultScanCount = 10
+
+// scanState is a single in-progress cursor: a stable, sorted snapshot of
+// key names plus how far through it the caller has consumed.
+type scanState struct {
+	keys []string
+	pos  int
+}
+
+// Scan returns up to count keys from this db, along with a cursor to pass
+// back in for the next batch. Pass cursor 0 to start a new scan. A
+// returned nextCursor of 0 means the scan is complete.
+//
+// Unlike Redis's reverse-bit cursor over hash table buckets (not
+// reproducible on top of a Go map), Scan takes a sorted snapshot of key
+// names on the first call (cursor 0) and walks it count at a time. This
+// means a Scan is stable against concurrent writes to db for its
+// duration, but keys added after the snapshot was taken will not be
+// seen until a fresh scan is started.
+func (db *RedisDb) Scan(cursor uint64, match string, count int64) (nextCursor uint64, keys []string) {
+	return db.scan(cursor, match, count, false)
+}
+
+// ScanExpiring is Scan over expiringKeys instead of keys.
+func (db *RedisDb) ScanExpiring(cursor uint64, match string, count int64) (nextCursor uint64, keys []string) {
+	return db.scan(cursor, match, count, true)
+}
+
+func (db *RedisDb) scan(cursor uint64, match string, count int64, expiring bool) (uint64, []string) {
+	if count <= 0 {
+		count = defaultScanCount
+	}
+
+	db.scanMu.Lock()
+	defer db.scanMu.Unlock()
+
+	st, ok := db.scans[cursor]
+	if cursor == 0 || !ok {
+		st = db.newScanState(expiring)
+	} else {
+		delete(db.scans, cursor)
+	}
+
+	end := st.pos + int(count)
+	if end > len(st.keys) {
+		end = len(st.keys)
+	}
+	var batch []string
+	for _, k := range st.keys[st.pos:end] {
+		if match == "" || matchesPattern(match, k) {
+			batch = append(batch, k)
+		}
+	}
+	st.pos = end
+
+	if st.pos >= len(st.keys) {
+		return 0, batch
+	}
+
+	db.nextScanToken++
+	token := db.nextScanToken
+	db.scans[token] = st
+	return token, batch
+}
+
+// newScanState takes a sorted snapshot of db.keys (or db.expiringKeys) to
+// scan over.
+func (db *RedisDb) newScanState(expiring bool) *scanState {
+	db.Mu().RLock()
+	defer db.Mu().RUnlock()
+	src := db.keys
+	if expiring {
+		src = db.expiringKeys
+	}
+	keys := make([]string, 0, len(src))
+	for k := range src {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return &scanState{keys: keys}
+}
+
+// matchesPattern reports whether key matches a Redis-KEYS-style glob
+// pattern (*, ?, [...], \-escapes). Unlike path.Match, '/' is an ordinary
+// character here: key names are opaque strings, not filesystem paths, so
+// a pattern like "*" must match a key such as "user/123/session".
+func matchesPattern(pattern, key string) bool {
+	return globMatch([]byte(pattern), []byte(key))
+}
+
+// globMatch implements Redis-style glob matching over arbitrary byte
+// strings: '*' matches any run of bytes (including none), '?' matches any
+// single byte, "[...]" matches a character class (with "^" negation and
+// "a-z" ranges), and '\' escapes the next byte as a literal.
+func globMatch(pattern, s []byte) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if globMatch(pattern[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+			pattern, s = pattern[1:], s[1:]
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+			end := bytes.IndexByte(pattern[1:], ']')
+			if end == -1 {
+				if s[0] != '[' {
+					return false
+				}
+				pattern, s = pattern[1:], s[1:]
+				continue
+			}
+			end++ // account for the pattern[1:] offset above
+			class := pattern[1:end]
+			negate := len(class) > 0 && class[0] == '^'
+			if negate {
+				class = class[1:]
+			}
+			if matchClass(class, s[0]) == negate {
+				return false
+			}
+			pattern, s = pattern[end+1:], s[1:]
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			if len(s) == 0 || s[0] != pattern[0] {
+				return false
+			}
+			pattern, s = pattern[1:], s[1:]
+		default:
+			if len(s) == 0 || s[0] != pattern[0] {
+				return false
+			}
+			pattern, s = pattern[1:], s[1:]
+		}
+	}
+	return len(s) == 0
+}
+
+// matchClass reports whether c is a member of class, which may contain
+// literal bytes and "a-z"-style ranges.
+func matchClass(class []byte, c byte) bool {
+	for i := 0; i < len(class); i++ {
+		if i+2 < len(class) && class[i+1] == '-' {
+			lo, hi := class[i], class[i+2]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			if c >= lo && c <= hi {
+				return true
+			}
+			i += 2
+			continue
+		}
+		if class[i] == c {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package redis
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestScanPaginatesUntilExhausted(t *testing.T) {
+	db := NewRedisDb(0, nil)
+	want := []string{"a", "b", "c", "d", "e"}
+	for _, k := range want {
+		key := k
+		db.Set(&key, benchItem{})
+	}
+
+	var got []string
+	cursor := uint64(0)
+	for {
+		var batch []string
+		cursor, batch = db.Scan(cursor, "", 2)
+		got = append(got, batch...)
+		if cursor == 0 {
+			break
+		}
+	}
+
+	sort.Strings(got)
+	if len(got) != len(want) {
+		t.Fatalf("Scan returned %d keys, want %d: %v", len(got), len(want), got)
+	}
+	for i, k := range want {
+		if got[i] != k {
+			t.Fatalf("Scan result = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestScanMatchWithSlashInKey(t *testing.T) {
+	db := NewRedisDb(0, nil)
+	key := "user/123/session"
+	db.Set(&key, benchItem{})
+
+	_, batch := db.Scan(0, "*", 10)
+	if len(batch) != 1 || batch[0] != key {
+		t.Fatalf("Scan(\"*\") = %v, want [%q]", batch, key)
+	}
+}
+
+func TestScanExpiringOnlyReturnsExpiringKeys(t *testing.T) {
+	db := NewRedisDb(0, nil)
+	forever := "forever"
+	db.Set(&forever, benchItem{})
+	ttl := "ttl"
+	db.Set(&ttl, expiringBenchItem{})
+
+	_, batch := db.ScanExpiring(0, "", 10)
+	if len(batch) != 1 || batch[0] != ttl {
+		t.Fatalf("ScanExpiring() = %v, want [%q]", batch, ttl)
+	}
+}
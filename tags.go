@@ -0,0 +1,87 @@
+package redis
+
+// Tag associates key with one or more tags, letting callers group-invalidate
+// related keys later via FlushTag without scanning the whole keyspace.
+func (db *RedisDb) Tag(key *string, tags ...string) {
+	db.Mu().Lock()
+	defer db.Mu().Unlock()
+	for _, tag := range tags {
+		if db.tags[tag] == nil {
+			db.tags[tag] = make(map[string]struct{})
+		}
+		db.tags[tag][*key] = struct{}{}
+
+		if db.keyTags[*key] == nil {
+			db.keyTags[*key] = make(map[string]struct{})
+		}
+		db.keyTags[*key][tag] = struct{}{}
+	}
+}
+
+// Untag removes tags from key. Tags left with no keys are dropped.
+func (db *RedisDb) Untag(key *string, tags ...string) {
+	db.Mu().Lock()
+	defer db.Mu().Unlock()
+	for _, tag := range tags {
+		db.untag(key, tag)
+	}
+}
+
+// untag removes a single tag from key, cleaning up both index sides.
+func (db *RedisDb) untag(key *string, tag string) {
+	if keys, ok := db.tags[tag]; ok {
+		delete(keys, *key)
+		if len(keys) == 0 {
+			delete(db.tags, tag)
+		}
+	}
+	if tags, ok := db.keyTags[*key]; ok {
+		delete(tags, tag)
+		if len(tags) == 0 {
+			delete(db.keyTags, *key)
+		}
+	}
+}
+
+// untagAll removes every tag from key. Callers must hold db.Mu().
+func (db *RedisDb) untagAll(key *string) {
+	tags, ok := db.keyTags[*key]
+	if !ok {
+		return
+	}
+	for tag := range tags {
+		if keys, ok := db.tags[tag]; ok {
+			delete(keys, *key)
+			if len(keys) == 0 {
+				delete(db.tags, tag)
+			}
+		}
+	}
+	delete(db.keyTags, *key)
+}
+
+// KeysByTag returns every key currently carrying tag.
+func (db *RedisDb) KeysByTag(tag string) []string {
+	db.Mu().RLock()
+	defer db.Mu().RUnlock()
+	keys := make([]string, 0, len(db.tags[tag]))
+	for k := range db.tags[tag] {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// FlushTag deletes every key carrying tag and returns how many were
+// removed.
+func (db *RedisDb) FlushTag(tag string) int64 {
+	db.Mu().Lock()
+	defer db.Mu().Unlock()
+	var c int64
+	for k := range db.tags[tag] {
+		key := k
+		if db.delete(&key) {
+			c++
+		}
+	}
+	return c
+}
@@ -0,0 +1,87 @@
+package redis
+
+import "testing"
+
+func TestTagKeysByTag(t *testing.T) {
+	db := NewRedisDb(0, nil)
+	a, b, c := "a", "b", "c"
+	for _, k := range []*string{&a, &b, &c} {
+		db.Set(k, benchItem{})
+	}
+	db.Tag(&a, "users")
+	db.Tag(&b, "users", "sessions")
+
+	got := db.KeysByTag("users")
+	if len(got) != 2 {
+		t.Fatalf("KeysByTag(users) = %v, want 2 keys", got)
+	}
+	if len(db.KeysByTag("sessions")) != 1 {
+		t.Fatalf("KeysByTag(sessions) = %v, want [b]", db.KeysByTag("sessions"))
+	}
+	if len(db.KeysByTag("absent")) != 0 {
+		t.Fatalf("KeysByTag(absent) should be empty")
+	}
+}
+
+func TestUntagRemovesFromBothIndexes(t *testing.T) {
+	db := NewRedisDb(0, nil)
+	a := "a"
+	db.Set(&a, benchItem{})
+	db.Tag(&a, "users", "sessions")
+
+	db.Untag(&a, "users")
+
+	if len(db.KeysByTag("users")) != 0 {
+		t.Fatalf("KeysByTag(users) should be empty after Untag")
+	}
+	if len(db.KeysByTag("sessions")) != 1 {
+		t.Fatalf("Untag(users) should not affect the sessions tag")
+	}
+	if len(db.keyTags[a]) != 1 {
+		t.Fatalf("keyTags[a] = %v, want only sessions left", db.keyTags[a])
+	}
+}
+
+func TestFlushTagDeletesEveryTaggedKeyAndCleansIndexes(t *testing.T) {
+	db := NewRedisDb(0, nil)
+	a, b, c := "a", "b", "c"
+	db.Set(&a, benchItem{})
+	db.Set(&b, benchItem{})
+	db.Set(&c, benchItem{})
+	db.Tag(&a, "users")
+	db.Tag(&b, "users")
+	db.Tag(&c, "sessions")
+
+	n := db.FlushTag("users")
+	if n != 2 {
+		t.Fatalf("FlushTag(users) = %d, want 2", n)
+	}
+	if db.Exists(&a) || db.Exists(&b) {
+		t.Fatalf("FlushTag(users) should have deleted both a and b")
+	}
+	if !db.Exists(&c) {
+		t.Fatalf("FlushTag(users) should not have touched c")
+	}
+	if _, ok := db.tags["users"]; ok {
+		t.Fatalf("tags[users] should be gone once empty")
+	}
+	if _, ok := db.keyTags[a]; ok {
+		t.Fatalf("keyTags[a] should be gone once a is deleted")
+	}
+}
+
+func TestDeleteCleansTagIndexesEvenWithoutFlushTag(t *testing.T) {
+	db := NewRedisDb(0, nil)
+	a := "a"
+	db.Set(&a, benchItem{})
+	db.Tag(&a, "users")
+
+	db.Delete(&a)
+
+	if len(db.KeysByTag("users")) != 0 {
+		t.Fatalf("deleting a tagged key directly should clean up its tags")
+	}
+	if _, ok := db.tags["users"]; ok {
+		t.Fatalf("tags[users] should be gone once its only key is deleted")
+	}
+}
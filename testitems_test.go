@@ -0,0 +1,46 @@
+package redis
+
+import "time"
+
+// expiringBenchItem is a minimal Item that always reports an expiry far in
+// the future, for tests that need to distinguish expiring from
+// non-expiring keys without exercising actual eviction.
+type expiringBenchItem struct{}
+
+func (expiringBenchItem) Value() interface{}                { return nil }
+func (expiringBenchItem) ValueType() uint64                 { return 0 }
+func (expiringBenchItem) ValueTypeFancy() string            { return "bench-expiring" }
+func (expiringBenchItem) Expiry() time.Time                 { return time.Now().Add(time.Hour) }
+func (expiringBenchItem) Expires() bool                     { return true }
+func (expiringBenchItem) OnDelete(key *string, db *RedisDb) {}
+
+// expiredBenchItem is a minimal Item whose expiry is already in the past,
+// for tests exercising eviction (the reaper, GetOrExpired).
+type expiredBenchItem struct{}
+
+func (expiredBenchItem) Value() interface{}                { return nil }
+func (expiredBenchItem) ValueType() uint64                 { return 0 }
+func (expiredBenchItem) ValueTypeFancy() string            { return "bench-expired" }
+func (expiredBenchItem) Expiry() time.Time                 { return time.Now().Add(-time.Hour) }
+func (expiredBenchItem) Expires() bool                     { return true }
+func (expiredBenchItem) OnDelete(key *string, db *RedisDb) {}
+
+// mutableExpiryItem is an Item that also implements ExpiryUpdater, backed
+// by a value so UpdateExpiry/Persist can mutate it in place.
+type mutableExpiryItem struct {
+	value   string
+	expires bool
+	expiry  time.Time
+}
+
+func (i *mutableExpiryItem) Value() interface{}                { return i.value }
+func (i *mutableExpiryItem) ValueType() uint64                 { return 1 }
+func (i *mutableExpiryItem) ValueTypeFancy() string            { return "mutable-expiry" }
+func (i *mutableExpiryItem) Expiry() time.Time                 { return i.expiry }
+func (i *mutableExpiryItem) Expires() bool                     { return i.expires }
+func (i *mutableExpiryItem) OnDelete(key *string, db *RedisDb) {}
+
+func (i *mutableExpiryItem) SetExpiry(t time.Time) {
+	i.expiry = t
+	i.expires = !t.IsZero()
+}